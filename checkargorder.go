@@ -8,6 +8,7 @@ package main
 
 import (
 	"debug/dwarf"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"go/ast"
@@ -55,6 +56,73 @@ func getFile(path string) []string {
 
 var verbose bool
 var errors bool
+var sweep bool
+var format string
+var baseline string
+var returnsFlag bool
+
+// Classification is the outcome of comparing a function's source argument
+// order against the order DWARF reports, or a reason why that comparison
+// couldn't be made.
+type Classification string
+
+const (
+	ClassOK            Classification = "ok"
+	ClassArgumentError Classification = "argumentError"
+	ClassTooManyPieces Classification = "tooManyPieces"
+	ClassMissingSource Classification = "missingSource"
+	ClassWrongOrder    Classification = "wrongOrder"
+	ClassMissingDwarf  Classification = "missingDwarf"
+	ClassDuplicated    Classification = "duplicated"
+)
+
+// Result is the per-function diagnostic record emitted in -format=json and
+// -format=ndjson mode.
+type Result struct {
+	Function       string         `json:"function"`
+	File           string         `json:"file"`
+	Line           int            `json:"line"`
+	Entry          uint64         `json:"entry"`
+	PrologueEnd    uint64         `json:"prologueEnd"`
+	Inlined        bool           `json:"inlined,omitempty"`
+	SourceArgs     []string       `json:"sourceArgs,omitempty"`
+	DwarfArgs      []string       `json:"dwarfArgs,omitempty"`
+	Classification Classification `json:"classification"`
+	Pieces         []op.Piece     `json:"pieces,omitempty"`
+
+	// SourceReturns, DwarfReturns and ReturnClassification are only
+	// populated when -returns is set.
+	SourceReturns        []string       `json:"sourceReturns,omitempty"`
+	DwarfReturns         []string       `json:"dwarfReturns,omitempty"`
+	ReturnClassification Classification `json:"returnClassification,omitempty"`
+}
+
+// Summary is the totals record emitted after all per-function records, in
+// every output format.
+type Summary struct {
+	NFunctions    int     `json:"nFunctions"`
+	ArgumentError int     `json:"argumentError"`
+	TooManyPieces int     `json:"tooManyPieces"`
+	MissingSource int     `json:"missingSource"`
+	WrongOrder    int     `json:"wrongOrder"`
+	MissingDwarf  int     `json:"missingDwarf"`
+	Duplicated    int     `json:"duplicated"`
+	Coverage      float64 `json:"coverage,omitempty"`
+
+	NInlined              int `json:"nInlined"`
+	InlinedArgumentError  int `json:"inlinedArgumentError"`
+	InlinedTooManyPieces  int `json:"inlinedTooManyPieces"`
+	InlinedMissingSource  int `json:"inlinedMissingSource"`
+	InlinedWrongOrder     int `json:"inlinedWrongOrder"`
+	InlinedMissingDwarf   int `json:"inlinedMissingDwarf"`
+	InlinedDuplicated     int `json:"inlinedDuplicated"`
+	NInlinedSkippedRanges int `json:"nInlinedSkippedRanges,omitempty"`
+
+	// WrongOrderReturns and MissingReturns are only populated when -returns
+	// is set.
+	WrongOrderReturns int `json:"wrongOrderReturns,omitempty"`
+	MissingReturns    int `json:"missingReturns,omitempty"`
+}
 
 type argsinfo struct {
 	nFunctions    int
@@ -64,11 +132,102 @@ type argsinfo struct {
 	wrongOrder    int
 	missingDwarf  int
 	duplicated    int
+
+	coverageSum   float64
+	coverageCount int
+
+	// Inlined holds the same buckets as above, but for DW_TAG_inlined_subroutine
+	// instances, which the Go compiler tends to describe less completely than
+	// out-of-line functions.
+	nInlined             int
+	inlinedArgumentError int
+	inlinedTooManyPieces int
+	inlinedMissingSource int
+	inlinedWrongOrder    int
+	inlinedMissingDwarf  int
+	inlinedDuplicated    int
+
+	// nInlinedSkippedRanges counts DW_TAG_inlined_subroutine instances whose
+	// PC is described by DW_AT_ranges rather than DW_AT_low_pc, which
+	// inlinedInstancePC doesn't resolve (see its doc comment); these
+	// instances are skipped entirely and not reflected in any other counter.
+	nInlinedSkippedRanges int
+
+	// wrongOrderReturns and missingReturns audit named result ordering,
+	// independently of the argument counters above, when -returns is set.
+	wrongOrderReturns int
+	missingReturns    int
+}
+
+func (a *argsinfo) tally(cls Classification) {
+	switch cls {
+	case ClassArgumentError:
+		a.argumentError++
+	case ClassTooManyPieces:
+		a.tooManyPieces++
+	case ClassMissingSource:
+		a.missingSource++
+	case ClassWrongOrder:
+		a.wrongOrder++
+	case ClassMissingDwarf:
+		a.missingDwarf++
+	case ClassDuplicated:
+		a.duplicated++
+	}
+}
+
+func (a *argsinfo) tallyInlined(cls Classification) {
+	switch cls {
+	case ClassArgumentError:
+		a.inlinedArgumentError++
+	case ClassTooManyPieces:
+		a.inlinedTooManyPieces++
+	case ClassMissingSource:
+		a.inlinedMissingSource++
+	case ClassWrongOrder:
+		a.inlinedWrongOrder++
+	case ClassMissingDwarf:
+		a.inlinedMissingDwarf++
+	case ClassDuplicated:
+		a.inlinedDuplicated++
+	}
+}
+
+func (a *argsinfo) summary() Summary {
+	s := Summary{
+		NFunctions:    a.nFunctions,
+		ArgumentError: a.argumentError,
+		TooManyPieces: a.tooManyPieces,
+		MissingSource: a.missingSource,
+		WrongOrder:    a.wrongOrder,
+		MissingDwarf:  a.missingDwarf,
+		Duplicated:    a.duplicated,
+
+		NInlined:              a.nInlined,
+		InlinedArgumentError:  a.inlinedArgumentError,
+		InlinedTooManyPieces:  a.inlinedTooManyPieces,
+		InlinedMissingSource:  a.inlinedMissingSource,
+		InlinedWrongOrder:     a.inlinedWrongOrder,
+		InlinedMissingDwarf:   a.inlinedMissingDwarf,
+		InlinedDuplicated:     a.inlinedDuplicated,
+		NInlinedSkippedRanges: a.nInlinedSkippedRanges,
+
+		WrongOrderReturns: a.wrongOrderReturns,
+		MissingReturns:    a.missingReturns,
+	}
+	if a.coverageCount > 0 {
+		s.Coverage = a.coverageSum / float64(a.coverageCount)
+	}
+	return s
 }
 
 func main() {
 	flag.BoolVar(&verbose, "v", verbose, "Say more about what is found")
 	flag.BoolVar(&errors, "e", errors, "Report more detail for errors")
+	flag.BoolVar(&sweep, "sweep", sweep, "Evaluate argument locations across the whole function body instead of only at the prologue end, reporting a DWARF location coverage fraction")
+	flag.StringVar(&format, "format", "csv", "Output format: csv, json or ndjson")
+	flag.StringVar(&baseline, "baseline", "", "Compare the input binary against this baseline binary and report only the functions whose classification changed, exiting non-zero if any regressed")
+	flag.BoolVar(&returnsFlag, "returns", returnsFlag, "Also audit the ordering of named return values (ABIInternal return slots), in addition to arguments")
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage of %s:\n", os.Args[0])
@@ -86,10 +245,67 @@ that are described at the function's "stop at" PC.
 		return
 	}
 
+	if baseline != "" {
+		runBaselineDiff(flag.Args()[0], baseline)
+		return
+	}
+
 	a := &argsinfo{}
+	var results []Result
 
+	emit := func(r Result) {
+		switch format {
+		case "ndjson":
+			b, err := json.Marshal(r)
+			must(err)
+			fmt.Println(string(b))
+		case "json":
+			results = append(results, r)
+		}
+	}
+
+	runAudit(flag.Args()[0], a, emit)
+
+	summary := a.summary()
+
+	switch format {
+	case "ndjson":
+		b, err := json.Marshal(summary)
+		must(err)
+		fmt.Println(string(b))
+	case "json":
+		out := struct {
+			Results []Result `json:"results"`
+			Summary Summary  `json:"summary"`
+		}{results, summary}
+		b, err := json.Marshal(out)
+		must(err)
+		fmt.Println(string(b))
+	default:
+		total := a.argumentError + a.tooManyPieces + a.missingSource + a.wrongOrder + a.missingDwarf + a.duplicated
+		header := "nFunctions,argumentError,tooManyPieces,missingSource,wrongOrder,missingDwarf,duplicated,1-totalErrors/nFunctions"
+		row := fmt.Sprintf("%d,%d,%d,%d,%d,%d,%d,%f", a.nFunctions, a.argumentError, a.tooManyPieces, a.missingSource, a.wrongOrder, a.missingDwarf, a.duplicated, 1.0-float64(total)/float64(a.nFunctions))
+		if sweep {
+			header += ",coverage"
+			row += fmt.Sprintf(",%f", summary.Coverage)
+		}
+		header += ",nInlined,inlinedArgumentError,inlinedTooManyPieces,inlinedMissingSource,inlinedWrongOrder,inlinedMissingDwarf,inlinedDuplicated,nInlinedSkippedRanges"
+		row += fmt.Sprintf(",%d,%d,%d,%d,%d,%d,%d,%d", a.nInlined, a.inlinedArgumentError, a.inlinedTooManyPieces, a.inlinedMissingSource, a.inlinedWrongOrder, a.inlinedMissingDwarf, a.inlinedDuplicated, a.nInlinedSkippedRanges)
+		if returnsFlag {
+			header += ",wrongOrderReturns,missingReturns"
+			row += fmt.Sprintf(",%d,%d", a.wrongOrderReturns, a.missingReturns)
+		}
+		fmt.Println(header)
+		fmt.Println(row)
+	}
+}
+
+// runAudit loads the binary at path and runs the source/DWARF argument order
+// comparison over all of its functions (and, via auditInlined, its inlined
+// instances), tallying results into a and reporting each one through emit.
+func runAudit(path string, a *argsinfo, emit func(Result)) {
 	bi := proc.NewBinaryInfo(runtime.GOOS, runtime.GOARCH)
-	bi.LoadBinaryInfo(flag.Args()[0], 0, []string{})
+	bi.LoadBinaryInfo(path, 0, []string{})
 
 	count := 0
 	countWithSortableArgs := 0
@@ -113,11 +329,11 @@ that are described at the function's "stop at" PC.
 
 		lines := getFile(file)
 		if len(lines) == 0 {
-			fmt.Printf("\tWARNING: SOURCE FILE NOT FOUND (%s in %s)\n", fn.Name, file)
+			fmt.Fprintf(os.Stderr, "\tWARNING: SOURCE FILE NOT FOUND (%s in %s)\n", fn.Name, file)
 			continue
 		}
 		if line >= len(lines) {
-			fmt.Printf("\tWARNING: LINE %d EXCEEDS RANGE %d (%s in %s)\n", line, len(lines)-1, fn.Name, file)
+			fmt.Fprintf(os.Stderr, "\tWARNING: LINE %d EXCEEDS RANGE %d (%s in %s)\n", line, len(lines)-1, fn.Name, file)
 			continue
 		}
 		dclln := strings.TrimSpace(lines[line-1])
@@ -127,15 +343,15 @@ that are described at the function's "stop at" PC.
 		}
 
 		if verbose {
-			fmt.Printf("function: %s\n", fn.Name)
-			fmt.Printf("\tDeclaration: %s\n", dclln)
+			fmt.Fprintf(os.Stderr, "function: %s\n", fn.Name)
+			fmt.Fprintf(os.Stderr, "\tDeclaration: %s\n", dclln)
 		}
 		a.nFunctions++
 		count++
 
-		sourceArgs, err := getSourceArgs(dclln)
+		sourceArgs, sourceReturns, err := getSourceArgs(dclln)
 		if err != nil {
-			fmt.Printf("\tWARNING: COULD NOT PARSE (%s in %s, err = %v)\n", fn.Name, file, err)
+			fmt.Fprintf(os.Stderr, "\tWARNING: COULD NOT PARSE (%s in %s, err = %v)\n", fn.Name, file, err)
 			continue
 		}
 
@@ -144,70 +360,232 @@ that are described at the function's "stop at" PC.
 		pc := fn.PrologueEndPC()
 
 		if verbose {
-			fmt.Printf("\tprologue ends at %#x (entry: %#x)\n", pc, fn.Entry)
+			fmt.Fprintf(os.Stderr, "\tprologue ends at %#x (entry: %#x)\n", pc, fn.Entry)
 		}
 
-		dwarfArgs, ok := a.orderArgsDwarf(bi, rdr, _fn.offset, pc)
-		if !ok {
+		if sweep {
+			coverage, ok := a.orderArgsDwarfSweep(bi, rdr, _fn.offset, fn.Entry, fn.End)
+			if ok {
+				a.coverageSum += coverage
+				a.coverageCount++
+			}
+		}
+
+		dwarfArgs, dwarfReturns, cls, pieces := orderArgsDwarf(bi, rdr, _fn.offset, pc)
+		if cls != "" {
+			a.tally(cls)
 			if verbose || errors {
-				fmt.Printf("\tERROR: ARGS FAILED (%s in %s)\n", fn.Name, file)
+				fmt.Fprintf(os.Stderr, "\tERROR: ARGS FAILED (%s in %s)\n", fn.Name, file)
 			}
+			emit(Result{Function: fn.Name, File: file, Line: line, Entry: fn.Entry, PrologueEnd: pc, SourceArgs: sourceArgs, Classification: cls, Pieces: pieces})
 			continue
 		}
 
 		if verbose {
-			fmt.Printf("\tDWARF arguments:\t%v\n", dwarfArgs)
-			fmt.Printf("\tSource arguments:\t%v\n", sourceArgs)
+			fmt.Fprintf(os.Stderr, "\tDWARF arguments:\t%v\n", dwarfArgs)
+			fmt.Fprintf(os.Stderr, "\tSource arguments:\t%v\n", sourceArgs)
 		}
 		countWithSortableArgs++
 
-		if len(dwarfArgs) > len(sourceArgs) {
-			a.missingSource++
+		switch {
+		case len(dwarfArgs) > len(sourceArgs):
+			cls = ClassMissingSource
 			if verbose || errors {
-				fmt.Printf("\tERROR: MISSING SOURCE ARGS (%s in %s, dwarfArgs=%v, sourceArgs=%v)\n", fn.Name, file, dwarfArgs, sourceArgs)
+				fmt.Fprintf(os.Stderr, "\tERROR: MISSING SOURCE ARGS (%s in %s, dwarfArgs=%v, sourceArgs=%v)\n", fn.Name, file, dwarfArgs, sourceArgs)
 			}
-			continue
-		}
-
-		if len(dwarfArgs) < len(sourceArgs) {
-			a.missingDwarf++
+		case len(dwarfArgs) < len(sourceArgs):
+			cls = ClassMissingDwarf
 			if verbose || errors {
-
-				fmt.Printf("\tERROR: MISSING DWARF ARGS (%s in %s, dwarfArgs=%v, sourceArgs=%v)\n", fn.Name, file, dwarfArgs, sourceArgs)
+				fmt.Fprintf(os.Stderr, "\tERROR: MISSING DWARF ARGS (%s in %s, dwarfArgs=%v, sourceArgs=%v)\n", fn.Name, file, dwarfArgs, sourceArgs)
+			}
+		default:
+			cls = ClassOK
+			for i := range dwarfArgs {
+				if dwarfArgs[i] != sourceArgs[i] {
+					cls = ClassWrongOrder
+					if verbose || errors {
+						fmt.Fprintf(os.Stderr, "\tERROR: ARGUMENT ORDER MISMATCH (%s in %s, %v vs %v)\n", fn.Name, file, dwarfArgs, sourceArgs)
+					}
+					break
+				}
 			}
-			continue
 		}
-
-		for i := range dwarfArgs {
-			if dwarfArgs[i] != sourceArgs[i] {
-				a.wrongOrder++
-				if verbose || errors {
-					fmt.Printf("\tERROR: ARGUMENT ORDER MISMATCH (%s in %s, %v vs %v)\n", fn.Name, file, dwarfArgs, sourceArgs)
+		a.tally(cls)
+
+		var returnCls Classification
+		if returnsFlag {
+			switch {
+			case len(dwarfReturns) > len(sourceReturns):
+				returnCls = ClassMissingSource
+				a.missingReturns++
+			case len(dwarfReturns) < len(sourceReturns):
+				returnCls = ClassMissingDwarf
+				a.missingReturns++
+			default:
+				returnCls = ClassOK
+				for i := range dwarfReturns {
+					if dwarfReturns[i] != sourceReturns[i] {
+						returnCls = ClassWrongOrder
+						a.wrongOrderReturns++
+						if verbose || errors {
+							fmt.Fprintf(os.Stderr, "\tERROR: RETURN ORDER MISMATCH (%s in %s, %v vs %v)\n", fn.Name, file, dwarfReturns, sourceReturns)
+						}
+						break
+					}
 				}
-				break
 			}
 		}
+
+		emit(Result{Function: fn.Name, File: file, Line: line, Entry: fn.Entry, PrologueEnd: pc, SourceArgs: sourceArgs, DwarfArgs: dwarfArgs, Classification: cls, SourceReturns: sourceReturns, DwarfReturns: dwarfReturns, ReturnClassification: returnCls})
 	}
 
 	if verbose {
-		fmt.Printf("non-inlined non-autogenerated: %d / %d\n", count, len(bi.Functions))
-		fmt.Printf("with sortable args: %d / %d\n", countWithSortableArgs, len(bi.Functions))
+		fmt.Fprintf(os.Stderr, "non-inlined non-autogenerated: %d / %d\n", count, len(bi.Functions))
+		fmt.Fprintf(os.Stderr, "with sortable args: %d / %d\n", countWithSortableArgs, len(bi.Functions))
 	}
 
-	// type argsinfo struct {
-	// 	nFunctions    int
-	// 	argumentError int
-	// 	tooManyPieces int
-	// 	missingSource int
-	// 	wrongOrder    int
-	// 	missingDwarf  int
-	// 	duplicated    int
-	// }
+	a.auditInlined(bi, emit)
+}
+
+// Regression is a function whose argument or (with -returns) return
+// classification differs between a baseline binary and the binary under
+// test.
+type Regression struct {
+	Function       string         `json:"function"`
+	Inlined        bool           `json:"inlined,omitempty"`
+	Baseline       Classification `json:"baseline"`
+	Current        Classification `json:"current"`
+	BaselineReturn Classification `json:"baselineReturn,omitempty"`
+	CurrentReturn  Classification `json:"currentReturn,omitempty"`
+}
+
+// classResult is the pair of classifications classify() tracks per
+// resultKey: the argument-order classification every Result carries, and,
+// when -returns is set, the return-order classification alongside it.
+type classResult struct {
+	Classification       Classification
+	ReturnClassification Classification
+}
+
+// resultKey identifies one matchable record out of a classify() run. A
+// plain function name is not enough: the same function can show up once
+// out-of-line and, separately, inlined at any number of call sites, and
+// those must not be folded into a single map entry. Call distinguishes
+// repeat inlined instances of the same function by the order they're
+// encountered, since their DWARF PCs aren't stable across the baseline and
+// current binaries.
+type resultKey struct {
+	Function string
+	Inlined  bool
+	Call     int
+}
+
+func (k resultKey) String() string {
+	if !k.Inlined {
+		return k.Function
+	}
+	return fmt.Sprintf("%s[inlined#%d]", k.Function, k.Call)
+}
+
+// runBaselineDiff audits path and baselinePath, matches the results by
+// fully-qualified function name (and, for inlined instances, by call-site
+// occurrence, see resultKey), and reports the functions whose
+// classification changed plus the functions that only exist on one side.
+// It calls os.Exit(1) if any function regressed (went from ok to a failing
+// classification), so that -baseline can gate CI on toolchain changes that
+// affect parameter location quality.
+func runBaselineDiff(path, baselinePath string) {
+	classify := func(p string) map[resultKey]classResult {
+		a := &argsinfo{}
+		m := map[resultKey]classResult{}
+		calls := map[string]int{}
+		runAudit(p, a, func(r Result) {
+			key := resultKey{Function: r.Function, Inlined: r.Inlined}
+			if r.Inlined {
+				key.Call = calls[r.Function]
+				calls[r.Function]++
+			}
+			m[key] = classResult{Classification: r.Classification, ReturnClassification: r.ReturnClassification}
+		})
+		return m
+	}
+
+	cur := classify(path)
+	base := classify(baselinePath)
+
+	changed := []Regression{}
+	for key, curCls := range cur {
+		if baseCls, ok := base[key]; ok && baseCls != curCls {
+			changed = append(changed, Regression{
+				Function:       key.Function,
+				Inlined:        key.Inlined,
+				Baseline:       baseCls.Classification,
+				Current:        curCls.Classification,
+				BaselineReturn: baseCls.ReturnClassification,
+				CurrentReturn:  curCls.ReturnClassification,
+			})
+		}
+	}
+	sort.Slice(changed, func(i, j int) bool { return changed[i].Function < changed[j].Function })
+
+	added := []string{}
+	for key := range cur {
+		if _, ok := base[key]; !ok {
+			added = append(added, key.String())
+		}
+	}
+	sort.Strings(added)
+
+	removed := []string{}
+	for key := range base {
+		if _, ok := cur[key]; !ok {
+			removed = append(removed, key.String())
+		}
+	}
+	sort.Strings(removed)
+
+	regressed := false
+	for _, c := range changed {
+		if c.Baseline == ClassOK && c.Current != ClassOK {
+			regressed = true
+		}
+		if c.BaselineReturn == ClassOK && c.CurrentReturn != ClassOK && c.CurrentReturn != "" {
+			regressed = true
+		}
+	}
 
-	fmt.Printf("nFunctions,argumentError,tooManyPieces,missingSource,wrongOrder,missingDwarf,duplicated,1-totalErrors/nFunctions\n")
-	total := a.argumentError + a.tooManyPieces + a.missingSource + a.wrongOrder + a.missingDwarf + a.duplicated
-	fmt.Printf("%d,%d,%d,%d,%d,%d,%d,%f\n", a.nFunctions, a.argumentError, a.tooManyPieces, a.missingSource, a.wrongOrder, a.missingDwarf, a.duplicated, 1.0 - float64(total)/float64(a.nFunctions))
+	switch format {
+	case "json", "ndjson":
+		out := struct {
+			Changed []Regression `json:"changed"`
+			Added   []string     `json:"added"`
+			Removed []string     `json:"removed"`
+		}{changed, added, removed}
+		b, err := json.Marshal(out)
+		must(err)
+		fmt.Println(string(b))
+	default:
+		for _, c := range changed {
+			name := c.Function
+			if c.Inlined {
+				name += "[inlined]"
+			}
+			fmt.Printf("CHANGED,%s,%s,%s\n", name, c.Baseline, c.Current)
+			if c.BaselineReturn != "" || c.CurrentReturn != "" {
+				fmt.Printf("CHANGED,%s,returns,%s,%s\n", name, c.BaselineReturn, c.CurrentReturn)
+			}
+		}
+		for _, name := range added {
+			fmt.Printf("ADDED,%s\n", name)
+		}
+		for _, name := range removed {
+			fmt.Printf("REMOVED,%s\n", name)
+		}
+	}
 
+	if regressed {
+		os.Exit(1)
+	}
 }
 
 type arg struct {
@@ -215,14 +593,41 @@ type arg struct {
 	addr int64
 }
 
-func (a *argsinfo) orderArgsDwarf(bi *proc.BinaryInfo, rdr *reader.Reader, offset dwarf.Offset, pc uint64) ([]string, bool) {
+// evalArgLocation evaluates a formal parameter's DW_AT_location at pc and
+// resolves it to a single address, using a sentinel CFA/FrameBase since the
+// callers only care about the parameter's storage slot, not a live frame.
+func evalArgLocation(bi *proc.BinaryInfo, e *dwarf.Entry, pc uint64) (int64, Classification, []op.Piece) {
+	const _cfa = 0x1000
+
+	addr, pieces, _, err := bi.Location(e, dwarf.AttrLocation, pc, op.DwarfRegisters{CFA: _cfa, FrameBase: _cfa})
+	if err != nil {
+		return 0, ClassArgumentError, nil
+	}
+	if len(pieces) != 0 {
+		var duplicatesSeen bool
+		addr, pieces, duplicatesSeen = coalescePieces(pieces)
+		if duplicatesSeen {
+			return 0, ClassDuplicated, pieces
+		}
+	}
+	if len(pieces) != 0 {
+		return 0, ClassTooManyPieces, pieces
+	}
+	return addr, "", nil
+}
+
+// orderArgsDwarf evaluates the DW_AT_location of every formal parameter of
+// the function at offset, at PC pc, and returns argument names in
+// DWARF-reported address order. Named results (DW_AT_variable_parameter)
+// are dropped unless -returns is set, in which case they're returned
+// separately, also in address order, instead of being mixed in with the
+// arguments.
+func orderArgsDwarf(bi *proc.BinaryInfo, rdr *reader.Reader, offset dwarf.Offset, pc uint64) ([]string, []string, Classification, []op.Piece) {
 	rdr.Seek(offset)
 	rdr.Next()
 
-	const _cfa = 0x1000
-
 	args := []arg{}
-	failed := false
+	rets := []arg{}
 
 	for {
 		e, err := rdr.Next()
@@ -243,64 +648,342 @@ func (a *argsinfo) orderArgsDwarf(bi *proc.BinaryInfo, rdr *reader.Reader, offse
 		name := e.Val(dwarf.AttrName).(string)
 		isvar := e.Val(dwarf.AttrVarParam).(bool)
 
-		if isvar && len(name) > 0 && name[0] == '~' {
+		// skip all return arguments, unless -returns asked to keep them
+		if isvar && !returnsFlag {
 			continue
 		}
 
-		// skip all return arguments
+		addr, cls, pieces := evalArgLocation(bi, e, pc)
+		if cls != "" {
+			if verbose || errors {
+				fmt.Fprintf(os.Stderr, "\t%s for %s: %v", cls, name, pieces)
+			}
+			return nil, nil, cls, pieces
+		}
+
 		if isvar {
+			rets = append(rets, arg{name, addr})
+		} else {
+			args = append(args, arg{name, addr})
+		}
+	}
+
+	sort.Slice(args, func(i, j int) bool {
+		return args[i].addr < args[j].addr
+	})
+	sort.Slice(rets, func(i, j int) bool {
+		return rets[i].addr < rets[j].addr
+	})
+
+	r := make([]string, len(args))
+	for i := range args {
+		r[i] = args[i].name
+	}
+
+	rr := make([]string, len(rets))
+	for i := range rets {
+		rr[i] = rets[i].name
+	}
+
+	return r, rr, "", nil
+}
+
+// collectLineSteps returns one PC per source line transition in [entry, end),
+// approximating the granularity of the line table without needing a
+// disassembler to find individual instruction boundaries.
+func collectLineSteps(bi *proc.BinaryInfo, entry, end uint64) []uint64 {
+	steps := []uint64{}
+	lastLine := -1
+	for pc := entry; pc < end; pc++ {
+		file, line, _ := bi.PCToLine(pc)
+		if file == "" {
 			continue
 		}
+		if line != lastLine {
+			steps = append(steps, pc)
+			lastLine = line
+		}
+	}
+	return steps
+}
+
+// orderArgsDwarfSweep evaluates the DW_AT_location of every (non-return)
+// formal parameter of the function at offset at every PC in [entry, end),
+// and returns the average, across arguments, of the fraction of those PCs
+// at which the location is defined and resolves to a single address (i.e.
+// not split across multiple, possibly overlapping, pieces). This is the
+// same "debug info quality" metric reported by GCC/LLVM coverage tools,
+// applied to orderArgsDwarf's notion of "describable".
+func (a *argsinfo) orderArgsDwarfSweep(bi *proc.BinaryInfo, rdr *reader.Reader, offset dwarf.Offset, entry, end uint64) (float64, bool) {
+	rdr.Seek(offset)
+	rdr.Next()
+
+	const _cfa = 0x1000
+
+	type param struct {
+		name    string
+		entry   *dwarf.Entry
+		defined int
+	}
 
-		addr, pieces, _, err := bi.Location(e, dwarf.AttrLocation, pc, op.DwarfRegisters{CFA: _cfa, FrameBase: _cfa})
+	params := []param{}
+
+	for {
+		e, err := rdr.Next()
 		if err != nil {
-			a.argumentError++
-			if verbose || errors {
-				fmt.Printf("\targument error for %s: %v", name, err)
-			}
-			failed = true
+			must(err)
+		}
+		if e == nil || e.Tag == 0 {
 			break
 		}
-		if len(pieces) != 0 {
-			duplicatesSeen := false
-			addr, pieces, duplicatesSeen = coalescePieces(pieces)
-			if duplicatesSeen {
-				if verbose || errors {
-					fmt.Printf("\tduplicates seen %s, %v", name, pieces)
+		rdr.SkipChildren()
+		if e.Tag != dwarf.TagFormalParameter {
+			continue
+		}
+
+		if e.Val(dwarf.AttrName) == nil {
+			continue
+		}
+		name := e.Val(dwarf.AttrName).(string)
+		isvar := e.Val(dwarf.AttrVarParam).(bool)
+
+		// skip all return arguments
+		if isvar {
+			continue
+		}
+
+		params = append(params, param{name: name, entry: e})
+	}
+
+	if len(params) == 0 {
+		return 0, false
+	}
+
+	steps := collectLineSteps(bi, entry, end)
+	if len(steps) == 0 {
+		return 0, false
+	}
+
+	for _, pc := range steps {
+		for i := range params {
+			_, pieces, _, err := bi.Location(params[i].entry, dwarf.AttrLocation, pc, op.DwarfRegisters{CFA: _cfa, FrameBase: _cfa})
+			if err != nil {
+				continue
+			}
+			if len(pieces) != 0 {
+				_, pieces, duplicatesSeen := coalescePieces(pieces)
+				if duplicatesSeen || len(pieces) != 0 {
+					continue
 				}
-				a.duplicated++
-				failed = true
-				break
 			}
+			params[i].defined++
+		}
+	}
 
+	coverageSum := 0.0
+	for i := range params {
+		coverageSum += float64(params[i].defined) / float64(len(steps))
+	}
+
+	return coverageSum / float64(len(params)), true
+}
+
+// paramName returns the name of a formal parameter DIE, following its
+// DW_AT_abstract_origin when the DIE itself (as is common for the formal
+// parameters of an inlined instance) doesn't carry a DW_AT_name.
+func paramName(bi *proc.BinaryInfo, e *dwarf.Entry) (string, bool) {
+	if v := e.Val(dwarf.AttrName); v != nil {
+		return v.(string), true
+	}
+	off, ok := e.Val(dwarf.AttrAbstractOrigin).(dwarf.Offset)
+	if !ok {
+		return "", false
+	}
+	rdr := bi.Images[0].DwarfReader()
+	rdr.Seek(off)
+	oe, err := rdr.Next()
+	if err != nil || oe == nil {
+		return "", false
+	}
+	if v := oe.Val(dwarf.AttrName); v != nil {
+		return v.(string), true
+	}
+	return "", false
+}
+
+// inlinedAbstractParams resolves the DW_TAG_subprogram that originOff points
+// to (the "abstract instance" the Go compiler emits once per inlined
+// function) and returns its name and the declaration order of its (non
+// return) formal parameters.
+func inlinedAbstractParams(bi *proc.BinaryInfo, originOff dwarf.Offset) (string, []string, bool) {
+	rdr := bi.Images[0].DwarfReader()
+	rdr.Seek(originOff)
+	se, err := rdr.Next()
+	if err != nil || se == nil || se.Tag != dwarf.TagSubprogram {
+		return "", nil, false
+	}
+	fnName, _ := se.Val(dwarf.AttrName).(string)
+	if fnName == "" {
+		return "", nil, false
+	}
+
+	sourceArgs := []string{}
+	for {
+		e, err := rdr.Next()
+		if err != nil {
+			must(err)
 		}
-		if len(pieces) != 0 {
-			a.tooManyPieces++
-			if verbose || errors {
-				fmt.Printf("\ttoo many pieces %s, %v", name, pieces)
-			}
-			failed = true
+		if e == nil || e.Tag == 0 {
+			break
+		}
+		rdr.SkipChildren()
+		if e.Tag != dwarf.TagFormalParameter {
+			continue
+		}
+		isvar, _ := e.Val(dwarf.AttrVarParam).(bool)
+		if isvar {
+			continue
+		}
+		name, ok := paramName(bi, e)
+		if !ok {
+			continue
+		}
+		sourceArgs = append(sourceArgs, name)
+	}
+	return fnName, sourceArgs, true
+}
+
+// inlinedInstancePC picks a PC inside the inlined instance at which to
+// evaluate its formal parameters' locations. It only handles the
+// DW_AT_low_pc case; instances described by DW_AT_ranges instead (multiple
+// disjoint PC ranges, which gc emits when an inlined call site's code is
+// split, e.g. by later optimization passes) are not resolved here and are
+// reported separately by the caller via nInlinedSkippedRanges so they don't
+// vanish from the audit without a trace.
+func inlinedInstancePC(ie *dwarf.Entry) (uint64, bool) {
+	if v, ok := ie.Val(dwarf.AttrLowpc).(uint64); ok {
+		return v, true
+	}
+	return 0, false
+}
+
+// orderArgsDwarfInlined is orderArgsDwarf's counterpart for a
+// DW_TAG_inlined_subroutine instance: the formal parameters live as direct
+// children of ie rather than of a DW_TAG_subprogram, and their names must be
+// resolved through their own DW_AT_abstract_origin.
+func orderArgsDwarfInlined(bi *proc.BinaryInfo, ie *dwarf.Entry, pc uint64) ([]string, Classification, []op.Piece) {
+	rdr := bi.Images[0].DwarfReader()
+	rdr.Seek(ie.Offset)
+	rdr.Next()
+
+	args := []arg{}
+
+	for {
+		e, err := rdr.Next()
+		if err != nil {
+			must(err)
+		}
+		if e == nil || e.Tag == 0 {
 			break
 		}
+		rdr.SkipChildren()
+		if e.Tag != dwarf.TagFormalParameter {
+			continue
+		}
 
-		args = append(args, arg{e.Val(dwarf.AttrName).(string), addr})
+		isvar, _ := e.Val(dwarf.AttrVarParam).(bool)
+		if isvar {
+			continue
+		}
+
+		name, ok := paramName(bi, e)
+		if !ok {
+			continue
+		}
+
+		addr, cls, pieces := evalArgLocation(bi, e, pc)
+		if cls != "" {
+			return nil, cls, pieces
+		}
+
+		args = append(args, arg{name, addr})
 	}
 
 	sort.Slice(args, func(i, j int) bool {
 		return args[i].addr < args[j].addr
 	})
 
-	if failed {
-		return nil, false
-	}
-
 	r := make([]string, len(args))
-
 	for i := range args {
 		r[i] = args[i].name
 	}
 
-	return r, true
+	return r, "", nil
+}
+
+// auditInlined walks every DW_TAG_inlined_subroutine in the binary and runs
+// the same source/DWARF argument order comparison as the main loop in
+// main(), tallying the results into the inlined* counters instead of the
+// top-level ones.
+func (a *argsinfo) auditInlined(bi *proc.BinaryInfo, emit func(Result)) {
+	rdr := bi.Images[0].DwarfReader()
+	rdr.Seek(0)
+
+	for {
+		e, err := rdr.Next()
+		if err != nil {
+			must(err)
+		}
+		if e == nil {
+			break
+		}
+		if e.Tag != dwarf.TagInlinedSubroutine {
+			continue
+		}
+
+		originOff, ok := e.Val(dwarf.AttrAbstractOrigin).(dwarf.Offset)
+		if !ok {
+			continue
+		}
+		fnName, sourceArgs, ok := inlinedAbstractParams(bi, originOff)
+		if !ok {
+			continue
+		}
+
+		pc, ok := inlinedInstancePC(e)
+		if !ok {
+			if e.Val(dwarf.AttrRanges) != nil {
+				a.nInlinedSkippedRanges++
+			}
+			continue
+		}
+
+		a.nInlined++
+
+		dwarfArgs, cls, pieces := orderArgsDwarfInlined(bi, e, pc)
+		if cls != "" {
+			a.tallyInlined(cls)
+			emit(Result{Function: fnName, Entry: pc, PrologueEnd: pc, Inlined: true, SourceArgs: sourceArgs, Classification: cls, Pieces: pieces})
+			continue
+		}
+
+		switch {
+		case len(dwarfArgs) > len(sourceArgs):
+			cls = ClassMissingSource
+		case len(dwarfArgs) < len(sourceArgs):
+			cls = ClassMissingDwarf
+		default:
+			cls = ClassOK
+			for i := range dwarfArgs {
+				if dwarfArgs[i] != sourceArgs[i] {
+					cls = ClassWrongOrder
+					break
+				}
+			}
+		}
+		a.tallyInlined(cls)
+		emit(Result{Function: fnName, Entry: pc, PrologueEnd: pc, Inlined: true, SourceArgs: sourceArgs, DwarfArgs: dwarfArgs, Classification: cls})
+	}
 }
 
 func coalescePieces(pieces []op.Piece) (int64, []op.Piece, bool) {
@@ -333,7 +1016,11 @@ func coalescePieces(pieces []op.Piece) (int64, []op.Piece, bool) {
 	return 0, pieces, duplicatesSeen
 }
 
-func getSourceArgs(dclln string) ([]string, error) {
+// getSourceArgs returns the names of dclln's formal parameters (in
+// declaration order) and, when -returns is set, the names of its results
+// (synthesizing "~rN" for unnamed ones, the same convention the Go compiler
+// uses for the corresponding DWARF formal parameters).
+func getSourceArgs(dclln string) ([]string, []string, error) {
 	if dclln[len(dclln)-1] != '}' {
 		dclln = dclln + "\n}"
 	}
@@ -343,17 +1030,18 @@ func getSourceArgs(dclln string) ([]string, error) {
 	var fset token.FileSet
 	f, err := parser.ParseFile(&fset, "in", source, parser.AllErrors)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	var v getSourceArgsVisitor
 	ast.Walk(&v, f)
 
-	return v.out, nil
+	return v.out, v.returns, nil
 }
 
 type getSourceArgsVisitor struct {
-	out []string
+	out     []string
+	returns []string
 }
 
 func (v *getSourceArgsVisitor) Visit(node ast.Node) ast.Visitor {
@@ -378,5 +1066,26 @@ func (v *getSourceArgsVisitor) Visit(node ast.Node) ast.Visitor {
 			}
 		}
 	}
+
+	if returnsFlag && fn.Type.Results != nil {
+		cnt := 0
+		for _, field := range fn.Type.Results.List {
+			if len(field.Names) == 0 {
+				// unnamed result, e.g. "func f() int"
+				v.returns = append(v.returns, fmt.Sprintf("~r%d", cnt))
+				cnt++
+				continue
+			}
+			for _, name := range field.Names {
+				if name == nil {
+					v.returns = append(v.returns, fmt.Sprintf("~r%d", cnt))
+				} else if name.Name != "_" {
+					v.returns = append(v.returns, name.Name)
+				}
+				cnt++
+			}
+		}
+	}
+
 	return nil
 }